@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// supportedPythonVersions is the set of interpreter versions this module
+// knows how to provision images for, oldest first.
+var supportedPythonVersions = []string{"3.8", "3.9", "3.10", "3.11", "3.12", "3.13"}
+
+var requiresPythonRe = regexp.MustCompile(`requires-python\s*=\s*"([^"]+)"`)
+
+// DetectInterpreters reads the `requires-python` constraint out of
+// pyproject.toml (e.g. ">=3.9,<3.13") and returns a comma-separated list of
+// the `python:<version>-slim` images that satisfy it. If pyproject.toml is
+// missing or declares no constraint, every supported version is returned.
+func (m *BetaPromptsSafety) DetectInterpreters(
+	ctx context.Context,
+	// Source directory containing pyproject.toml
+	source *Directory,
+) (string, error) {
+	contents, err := source.File("pyproject.toml").Contents(ctx)
+	if err != nil {
+		return strings.Join(pythonImages(supportedPythonVersions), ","), nil
+	}
+
+	match := requiresPythonRe.FindStringSubmatch(contents)
+	if match == nil {
+		return strings.Join(pythonImages(supportedPythonVersions), ","), nil
+	}
+
+	versions, err := matchingVersions(match[1], supportedPythonVersions)
+	if err != nil {
+		return "", fmt.Errorf("parsing requires-python constraint %q: %w", match[1], err)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no supported interpreter satisfies requires-python %q", match[1])
+	}
+
+	return strings.Join(pythonImages(versions), ","), nil
+}
+
+// TestMatrix runs RunTests once per interpreter version and aggregates the
+// per-version output. If versions is empty it is resolved via
+// DetectInterpreters.
+func (m *BetaPromptsSafety) TestMatrix(
+	ctx context.Context,
+	// Source directory containing tests
+	source *Directory,
+	// +optional
+	// Python versions to test against, e.g. ["3.10", "3.11"]
+	versions []string,
+	// +optional
+	// Additional pytest arguments
+	args []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
+) (string, error) {
+	if len(versions) == 0 {
+		detected, err := m.DetectInterpreters(ctx, source)
+		if err != nil {
+			return "", err
+		}
+		for _, image := range strings.Split(detected, ",") {
+			versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(image, "python:"), "-slim"))
+		}
+	}
+
+	var report strings.Builder
+	failed := false
+
+	for _, version := range versions {
+		out, err := m.runTestsOn(ctx, source, version, args, secrets, policy)
+		status := "PASS"
+		if err != nil {
+			status = "FAIL"
+			failed = true
+			out = err.Error()
+		}
+		fmt.Fprintf(&report, "=== python:%s-slim [%s] ===\n%s\n", version, status, out)
+	}
+
+	if failed {
+		return report.String(), fmt.Errorf("test matrix failed for one or more interpreters")
+	}
+	return report.String(), nil
+}
+
+// runTestsOn executes the test suite inside a container built from the
+// given interpreter version, mirroring RunTests but with a pinned image. It
+// routes through the same containerFrom/venvOn helpers RunTests uses instead
+// of building a bare container from scratch, so matrix runs get
+// build-essential/git/curl, benefit from the venv/pip cache (keyed per
+// version so incompatible wheels don't collide), and honor secrets/policy
+// like every other execution entrypoint in this module.
+func (m *BetaPromptsSafety) runTestsOn(
+	ctx context.Context,
+	source *Directory,
+	version string,
+	args []string,
+	secrets map[string]*Secret,
+	policy *SafetyPolicy,
+) (string, error) {
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	image := fmt.Sprintf("python:%s-slim", version)
+
+	venv, err := m.venvOn(ctx, m.containerFrom(ctx, "linux/amd64", image), source, []string{"test"}, version)
+	if err != nil {
+		return "", err
+	}
+
+	container := WithVenv(m.containerFrom(ctx, "linux/amd64", image), venv).
+		WithDirectory("/workspace", source)
+	container = applySecrets(container, secrets)
+
+	if len(args) == 0 {
+		args = []string{"-v"}
+	}
+	pytestArgs := append([]string{"pytest"}, args...)
+
+	return execWithPolicy(container, pytestArgs, policy, ContainerWithExecOpts{}).Stdout(ctx)
+}
+
+// pythonImages maps bare version numbers to their `-slim` image references.
+func pythonImages(versions []string) []string {
+	images := make([]string, len(versions))
+	for i, v := range versions {
+		images[i] = fmt.Sprintf("python:%s-slim", v)
+	}
+	return images
+}
+
+// matchingVersions filters candidates against a PEP 440-style
+// comma-separated constraint such as ">=3.9,<3.13".
+func matchingVersions(constraint string, candidates []string) ([]string, error) {
+	clauses := strings.Split(constraint, ",")
+
+	var matches []string
+	for _, candidate := range candidates {
+		ok := true
+		for _, clause := range clauses {
+			satisfied, err := satisfiesClause(candidate, strings.TrimSpace(clause))
+			if err != nil {
+				return nil, err
+			}
+			if !satisfied {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches, nil
+}
+
+// satisfiesClause evaluates a single constraint clause (">=3.9", "<3.13",
+// "==3.11", "!=3.9") against a version string.
+func satisfiesClause(version, clause string) (bool, error) {
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		if strings.HasPrefix(clause, op) {
+			want := strings.TrimSpace(strings.TrimPrefix(clause, op))
+			cmp, err := compareVersions(version, want)
+			if err != nil {
+				return false, err
+			}
+			switch op {
+			case ">=":
+				return cmp >= 0, nil
+			case "<=":
+				return cmp <= 0, nil
+			case "==":
+				return cmp == 0, nil
+			case "!=":
+				return cmp != 0, nil
+			case ">":
+				return cmp > 0, nil
+			case "<":
+				return cmp < 0, nil
+			}
+		}
+	}
+	return false, fmt.Errorf("unsupported constraint clause %q", clause)
+}
+
+// compareVersions compares two dotted version strings component-wise,
+// returning -1, 0, or 1.
+func compareVersions(a, b string) (int, error) {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var av, bv int
+		var err error
+		if i < len(aParts) {
+			if av, err = strconv.Atoi(aParts[i]); err != nil {
+				return 0, fmt.Errorf("invalid version component %q", aParts[i])
+			}
+		}
+		if i < len(bParts) {
+			if bv, err = strconv.Atoi(bParts[i]); err != nil {
+				return 0, fmt.Errorf("invalid version component %q", bParts[i])
+			}
+		}
+		if av != bv {
+			if av < bv {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
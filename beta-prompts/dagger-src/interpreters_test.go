@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"3.9", "3.9", 0},
+		{"3.9", "3.10", -1},
+		{"3.10", "3.9", 1},
+		{"3.8", "3.13", -1},
+		{"3.11", "3.11.0", 0},
+	}
+	for _, tc := range cases {
+		got, err := compareVersions(tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("compareVersions(%q, %q): %v", tc.a, tc.b, err)
+		}
+		if got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestCompareVersionsInvalid(t *testing.T) {
+	if _, err := compareVersions("3.x", "3.9"); err == nil {
+		t.Error("compareVersions with a non-numeric component: want error, got nil")
+	}
+}
+
+func TestSatisfiesClause(t *testing.T) {
+	cases := []struct {
+		version, clause string
+		want            bool
+	}{
+		{"3.10", ">=3.9", true},
+		{"3.9", ">=3.9", true},
+		{"3.8", ">=3.9", false},
+		{"3.12", "<3.13", true},
+		{"3.13", "<3.13", false},
+		{"3.11", "==3.11", true},
+		{"3.11", "!=3.11", false},
+		{"3.11", "!=3.10", true},
+	}
+	for _, tc := range cases {
+		got, err := satisfiesClause(tc.version, tc.clause)
+		if err != nil {
+			t.Fatalf("satisfiesClause(%q, %q): %v", tc.version, tc.clause, err)
+		}
+		if got != tc.want {
+			t.Errorf("satisfiesClause(%q, %q) = %v, want %v", tc.version, tc.clause, got, tc.want)
+		}
+	}
+}
+
+func TestSatisfiesClauseUnsupportedOperator(t *testing.T) {
+	if _, err := satisfiesClause("3.11", "~=3.11"); err == nil {
+		t.Error("satisfiesClause with an unsupported operator: want error, got nil")
+	}
+}
+
+func TestMatchingVersions(t *testing.T) {
+	candidates := []string{"3.8", "3.9", "3.10", "3.11", "3.12", "3.13"}
+
+	got, err := matchingVersions(">=3.9,<3.13", candidates)
+	if err != nil {
+		t.Fatalf("matchingVersions: %v", err)
+	}
+	want := []string{"3.9", "3.10", "3.11", "3.12"}
+	if len(got) != len(want) {
+		t.Fatalf("matchingVersions(\">=3.9,<3.13\") = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("matchingVersions(\">=3.9,<3.13\")[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMatchingVersionsNoMatch(t *testing.T) {
+	got, err := matchingVersions(">=4.0", []string{"3.8", "3.9"})
+	if err != nil {
+		t.Fatalf("matchingVersions: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("matchingVersions(\">=4.0\") = %v, want empty", got)
+	}
+}
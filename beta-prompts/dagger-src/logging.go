@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLine is a single timestamped line of container output, tagged with the
+// stream it came from.
+type LogLine struct {
+	Timestamp string `json:"timestamp"`
+	Stream    string `json:"stream"` // "stdout" or "stderr"
+	Text      string `json:"text"`
+}
+
+// RunResult is the structured result of a single container execution. The
+// *JSON variants and ShellScript return it; PythonScript, RunTests and
+// ShellCommand still return only stdout.
+type RunResult struct {
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	ExitCode   int       `json:"exitCode"`
+	DurationMS int64     `json:"durationMs"`
+	Logs       []LogLine `json:"logs"`
+}
+
+// BufferedLogger accumulates stdout/stderr lines from a container run so
+// they can be returned as RunResult.Logs. Dagger's Container.Stdout/Stderr
+// don't resolve until the underlying exec has finished, so this only ever
+// replays output that already happened — there is no live tailing here, and
+// no method on this type should imply otherwise.
+type BufferedLogger struct {
+	mu  sync.Mutex
+	all []LogLine
+}
+
+// NewBufferedLogger creates a logger ready to accept Write calls.
+func NewBufferedLogger() *BufferedLogger {
+	return &BufferedLogger{}
+}
+
+// Write appends a line to the log, attaching a timestamp and the given
+// stream tag.
+func (b *BufferedLogger) Write(stream, text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, LogLine{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Stream:    stream,
+		Text:      text,
+	})
+}
+
+// WriteAll splits text into lines and writes each one under stream.
+func (b *BufferedLogger) WriteAll(stream, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			continue
+		}
+		b.Write(stream, line)
+	}
+}
+
+// Lines returns every line written to the logger so far, in order.
+func (b *BufferedLogger) Lines() []LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	all := make([]LogLine, len(b.all))
+	copy(all, b.all)
+	return all
+}
+
+// captureRunResult executes container (which must already have its command
+// set via WithExec), collecting stdout/stderr into a RunResult without
+// failing the call on a non-zero exit code. Stdout/Stderr/ExitCode each
+// block until the exec completes, so this always returns the result of a
+// finished run, not a running one.
+func captureRunResult(ctx context.Context, container *Container) (*RunResult, error) {
+	start := time.Now()
+
+	stdout, err := container.Stdout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := container.Stderr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exitCode, err := container.ExitCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := NewBufferedLogger()
+	logger.WriteAll("stdout", stdout)
+	logger.WriteAll("stderr", stderr)
+
+	return &RunResult{
+		Stdout:     stdout,
+		Stderr:     stderr,
+		ExitCode:   exitCode,
+		DurationMS: time.Since(start).Milliseconds(),
+		Logs:       logger.Lines(),
+	}, nil
+}
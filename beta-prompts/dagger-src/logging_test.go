@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestBufferedLoggerWriteAndLines(t *testing.T) {
+	logger := NewBufferedLogger()
+	logger.Write("stdout", "first")
+	logger.Write("stderr", "second")
+
+	lines := logger.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("len(Lines()) = %d, want 2", len(lines))
+	}
+	if lines[0].Stream != "stdout" || lines[0].Text != "first" {
+		t.Errorf("lines[0] = %+v, want stream=stdout text=first", lines[0])
+	}
+	if lines[1].Stream != "stderr" || lines[1].Text != "second" {
+		t.Errorf("lines[1] = %+v, want stream=stderr text=second", lines[1])
+	}
+	if lines[0].Timestamp == "" {
+		t.Errorf("lines[0].Timestamp is empty, want an RFC3339Nano timestamp")
+	}
+}
+
+func TestBufferedLoggerWriteAllSkipsBlankLines(t *testing.T) {
+	logger := NewBufferedLogger()
+	logger.WriteAll("stdout", "line one\n\nline two\n")
+
+	lines := logger.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("len(Lines()) = %d, want 2 (blank line should be skipped): %+v", len(lines), lines)
+	}
+	if lines[0].Text != "line one" || lines[1].Text != "line two" {
+		t.Errorf("lines = %+v, want [line one, line two]", lines)
+	}
+}
+
+func TestBufferedLoggerLinesReturnsCopy(t *testing.T) {
+	logger := NewBufferedLogger()
+	logger.Write("stdout", "first")
+
+	lines := logger.Lines()
+	lines[0].Text = "mutated"
+
+	if got := logger.Lines()[0].Text; got != "first" {
+		t.Errorf("Lines() exposed internal state: got %q after mutating a prior copy, want %q", got, "first")
+	}
+}
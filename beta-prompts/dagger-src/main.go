@@ -5,7 +5,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 )
 
 type BetaPromptsSafety struct{}
@@ -15,13 +14,25 @@ func (m *BetaPromptsSafety) Container(
 	ctx context.Context,
 	// +optional
 	platform string,
+) *Container {
+	return m.containerFrom(ctx, platform, "python:3.11-slim")
+}
+
+// containerFrom builds the shared base container (apt packages, upgraded
+// pip, workdir, env) from image, so callers that need a non-default
+// interpreter build — TestMatrix's per-version containers, for one — get the
+// same tooling as Container instead of reimplementing setup from scratch.
+func (m *BetaPromptsSafety) containerFrom(
+	ctx context.Context,
+	platform string,
+	image string,
 ) *Container {
 	if platform == "" {
 		platform = "linux/amd64"
 	}
-	
+
 	return dag.Container(ContainerOpts{Platform: Platform(platform)}).
-		From("python:3.11-slim").
+		From(image).
 		WithExec([]string{"apt-get", "update"}).
 		WithExec([]string{"apt-get", "install", "-y", "git", "curl", "build-essential"}).
 		WithExec([]string{"pip", "install", "--upgrade", "pip"}).
@@ -40,34 +51,49 @@ func (m *BetaPromptsSafety) PythonScript(
 	// +optional
 	// Environment variables
 	env []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
 ) (string, error) {
-	container := m.Container(ctx, "linux/amd64").
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	venv, err := m.Venv(ctx, source, nil)
+	if err != nil {
+		return "", err
+	}
+
+	container := WithVenv(m.Container(ctx, "linux/amd64"), venv).
 		WithDirectory("/workspace", source)
-	
+
 	// Add environment variables
 	for _, e := range env {
 		container = container.WithEnvVariable(e, e)
 	}
-	
-	// Install dependencies from pyproject.toml
-	container = container.
-		WithExec([]string{"sh", "-c", "if [ -f pyproject.toml ]; then pip install -e .; fi"})
-	
+	container = applySecrets(container, secrets)
+
 	// Execute the Python script
-	return container.
-		WithExec([]string{"python", script}).
+	return execWithPolicy(container, []string{"python", script}, policy, ContainerWithExecOpts{}).
 		Stdout(ctx)
 }
 
-// InstallDependencies installs Python dependencies from pyproject.toml
+// InstallDependencies builds the venv for source and returns the `pip list`
+// output of the packages installed in it
 func (m *BetaPromptsSafety) InstallDependencies(
 	ctx context.Context,
 	// Source directory containing pyproject.toml
 	source *Directory,
 ) (string, error) {
-	return m.Container(ctx, "linux/amd64").
+	venv, err := m.Venv(ctx, source, nil)
+	if err != nil {
+		return "", err
+	}
+	return WithVenv(m.Container(ctx, "linux/amd64"), venv).
 		WithDirectory("/workspace", source).
-		WithExec([]string{"pip", "install", "-e", "."}).
+		WithExec([]string{"pip", "list"}).
 		Stdout(ctx)
 }
 
@@ -79,34 +105,32 @@ func (m *BetaPromptsSafety) RunTests(
 	// +optional
 	// Additional pytest arguments
 	args []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
 ) (string, error) {
-	container := m.Container(ctx, "linux/amd64").
-		WithDirectory("/workspace", source).
-		WithExec([]string{"pip", "install", "-e", "."})
-	
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	venv, err := m.Venv(ctx, source, []string{"test"})
+	if err != nil {
+		return "", err
+	}
+
+	container := WithVenv(m.Container(ctx, "linux/amd64"), venv).
+		WithDirectory("/workspace", source)
+	container = applySecrets(container, secrets)
+
 	if len(args) == 0 {
 		args = []string{"-v"}
 	}
-	
+
 	pytestArgs := append([]string{"pytest"}, args...)
-	
-	return container.
-		WithExec(pytestArgs).
-		Stdout(ctx)
-}
 
-// ValidateScript performs safety validation on a Python script
-func (m *BetaPromptsSafety) ValidateScript(
-	ctx context.Context,
-	// Source directory
-	source *Directory,
-	// Script to validate
-	script string,
-) (string, error) {
-	return m.Container(ctx, "linux/amd64").
-		WithDirectory("/workspace", source).
-		WithExec([]string{"python", "-m", "py_compile", script}).
-		WithExec([]string{"python", "-c", fmt.Sprintf("import ast; ast.parse(open('%s').read())", script)}).
+	return execWithPolicy(container, pytestArgs, policy, ContainerWithExecOpts{}).
 		Stdout(ctx)
 }
 
@@ -120,16 +144,25 @@ func (m *BetaPromptsSafety) ShellCommand(
 	// +optional
 	// Environment variables
 	env []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
 ) (string, error) {
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
 	container := m.Container(ctx, "linux/amd64").
 		WithDirectory("/workspace", source)
-	
+
 	// Add environment variables
 	for _, e := range env {
 		container = container.WithEnvVariable(e, e)
 	}
-	
-	return container.
-		WithExec([]string{"sh", "-c", command}).
+	container = applySecrets(container, secrets)
+
+	return execWithPolicy(container, []string{"sh", "-c", command}, policy, ContainerWithExecOpts{}).
 		Stdout(ctx)
-}
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PythonScriptJSON runs script the same way PythonScript does, but returns
+// the full RunResult (stdout, stderr, exit code, duration, log lines) as
+// JSON instead of only stdout.
+func (m *BetaPromptsSafety) PythonScriptJSON(
+	ctx context.Context,
+	// Source directory containing the script
+	source *Directory,
+	// Script filename to execute
+	script string,
+	// +optional
+	// Environment variables
+	env []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
+) (string, error) {
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	venv, err := m.Venv(ctx, source, nil)
+	if err != nil {
+		return "", err
+	}
+
+	container := WithVenv(m.Container(ctx, "linux/amd64"), venv).
+		WithDirectory("/workspace", source)
+	for _, e := range env {
+		container = container.WithEnvVariable(e, e)
+	}
+	container = applySecrets(container, secrets)
+	container = execWithPolicy(container, []string{"python", script}, policy, ContainerWithExecOpts{Expect: ReturnTypeAny})
+
+	return marshalRunResult(captureRunResult(ctx, container))
+}
+
+// RunTestsJSON runs the test suite the same way RunTests does, but returns
+// the full RunResult as JSON instead of only stdout.
+func (m *BetaPromptsSafety) RunTestsJSON(
+	ctx context.Context,
+	// Source directory containing tests
+	source *Directory,
+	// +optional
+	// Additional pytest arguments
+	args []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
+) (string, error) {
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	venv, err := m.Venv(ctx, source, []string{"test"})
+	if err != nil {
+		return "", err
+	}
+
+	container := WithVenv(m.Container(ctx, "linux/amd64"), venv).
+		WithDirectory("/workspace", source)
+	container = applySecrets(container, secrets)
+
+	if len(args) == 0 {
+		args = []string{"-v"}
+	}
+	pytestArgs := append([]string{"pytest"}, args...)
+	container = execWithPolicy(container, pytestArgs, policy, ContainerWithExecOpts{Expect: ReturnTypeAny})
+
+	return marshalRunResult(captureRunResult(ctx, container))
+}
+
+// ShellCommandJSON runs command the same way ShellCommand does, but returns
+// the full RunResult as JSON instead of only stdout.
+func (m *BetaPromptsSafety) ShellCommandJSON(
+	ctx context.Context,
+	// Source directory
+	source *Directory,
+	// Command to execute
+	command string,
+	// +optional
+	// Environment variables
+	env []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
+) (string, error) {
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	container := m.Container(ctx, "linux/amd64").
+		WithDirectory("/workspace", source)
+	for _, e := range env {
+		container = container.WithEnvVariable(e, e)
+	}
+	container = applySecrets(container, secrets)
+	container = execWithPolicy(container, []string{"sh", "-c", command}, policy, ContainerWithExecOpts{Expect: ReturnTypeAny})
+
+	return marshalRunResult(captureRunResult(ctx, container))
+}
+
+// ValidateScriptJSON runs the same bandit/ruff/pip-audit/semgrep pipeline
+// ValidateScript does, via the shared runValidationPipeline helper, and
+// returns both the syntax-check RunResult (stdout, stderr, exit code,
+// duration, log lines) and the merged ValidationReport as one JSON
+// document, instead of only one or the other.
+func (m *BetaPromptsSafety) ValidateScriptJSON(
+	ctx context.Context,
+	// Source directory
+	source *Directory,
+	// Script to validate
+	script string,
+) (string, error) {
+	runResult, report, err := m.runValidationPipeline(ctx, source, script)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(struct {
+		RunResult *RunResult        `json:"runResult"`
+		Report    *ValidationReport `json:"report"`
+	}{runResult, report})
+	if err != nil {
+		return "", fmt.Errorf("marshaling validate script result: %w", err)
+	}
+	return string(data), nil
+}
+
+func marshalRunResult(result *RunResult, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("marshaling run result: %w", err)
+	}
+	return string(data), nil
+}
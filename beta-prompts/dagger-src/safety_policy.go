@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SafetyPolicy bounds what a sandboxed execution is allowed to do: how much
+// network access it gets, how many resources it may consume, and whether
+// its root filesystem is writable. A nil policy falls back to the
+// permissive defaults the container already had.
+type SafetyPolicy struct {
+	// NetworkMode is one of "none", "offline", or "allowlist". Both route
+	// the container's HTTP(S)_PROXY through a tinyproxy sidecar this module
+	// controls rather than the sandboxed container's own network
+	// namespace: "none"/"offline" point at a sidecar configured to allow
+	// nothing, "allowlist" at one scoped to AllowedHosts. This only governs
+	// clients that honor HTTP(S)_PROXY — see the proxy-vs-netns tradeoff
+	// note on networkProxyService.
+	NetworkMode string
+	// AllowedHosts is the set of hostnames reachable when NetworkMode is
+	// "allowlist".
+	AllowedHosts []string
+	// CPUQuota caps CPU time as a percentage of one core (100 = one core),
+	// enforced via a cgroup v2 cpu.max limit.
+	CPUQuota int
+	// MemoryLimit caps memory in megabytes, enforced via `ulimit -v`.
+	MemoryLimit int
+	// Timeout caps wall-clock execution time in seconds, enforced as a
+	// context deadline around the call that runs the command.
+	Timeout int
+	// ReadOnlyRoot strips write permission from /workspace before the
+	// command runs, so it can't persist changes there.
+	ReadOnlyRoot bool
+}
+
+// policyContext derives a context that is canceled once policy.Timeout
+// elapses, so a hung script aborts the underlying Dagger call instead of
+// running forever. Returns ctx unchanged, with a no-op cancel, when policy
+// is nil or sets no timeout.
+func policyContext(ctx context.Context, policy *SafetyPolicy) (context.Context, context.CancelFunc) {
+	if policy == nil || policy.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(policy.Timeout)*time.Second)
+}
+
+// execWithPolicy runs cmd in container with policy enforced around the
+// actual process. Enforcement has to wrap the same WithExec call that runs
+// cmd, rather than live in an earlier step, because ulimits and cgroup
+// membership set in one WithExec don't carry over to the next one. Network
+// policy is the exception: it's applied to container itself (a service
+// binding plus env vars), not to the wrapped command, so it's attached
+// before the wrap rather than folded into it.
+func execWithPolicy(container *Container, cmd []string, policy *SafetyPolicy, opts ContainerWithExecOpts) *Container {
+	if policy == nil {
+		return container.WithExec(cmd, opts)
+	}
+
+	container = applyNetworkPolicy(container, policy)
+
+	wrapped := cmd
+	if policy.MemoryLimit > 0 {
+		wrapped = ulimitWrap(policy.MemoryLimit, wrapped)
+	}
+	if policy.CPUQuota > 0 {
+		wrapped = cgroupWrap(policy.CPUQuota, wrapped)
+	}
+	if policy.ReadOnlyRoot {
+		wrapped = readOnlyRootWrap(wrapped)
+	}
+
+	return container.WithExec(wrapped, opts)
+}
+
+// shWrap turns script into a shell invocation whose "$@" is inner, so
+// enforcement steps compose: each wrap's "inner" argument becomes the exact
+// argv the previous (sh -c script "sh" inner...) invocation execs once its
+// own setup is done.
+func shWrap(script string, inner []string) []string {
+	return append([]string{"sh", "-c", script, "sh"}, inner...)
+}
+
+// ulimitWrap caps virtual memory to memoryMB before exec'ing inner.
+func ulimitWrap(memoryMB int, inner []string) []string {
+	script := fmt.Sprintf("ulimit -v %d\nexec \"$@\"\n", memoryMB*1024)
+	return shWrap(script, inner)
+}
+
+// cgroupWrap caps CPU time to cpuQuotaPercent percent of one core via a
+// cgroup v2 cpu.max limit, on a 100ms accounting period. Setup is
+// best-effort: if the engine doesn't delegate cgroup v2 to this container,
+// the `|| true` fallbacks let the command still run, just unthrottled.
+func cgroupWrap(cpuQuotaPercent int, inner []string) []string {
+	quotaUs := cpuQuotaPercent * 1000
+	const periodUs = 100000
+	script := fmt.Sprintf(`if [ -f /sys/fs/cgroup/cgroup.controllers ]; then
+  cg="/sys/fs/cgroup/safety-$$"
+  mkdir -p "$cg" 2>/dev/null || true
+  echo "%d %d" > "$cg/cpu.max" 2>/dev/null || true
+  echo $$ > "$cg/cgroup.procs" 2>/dev/null || true
+fi
+exec "$@"
+`, quotaUs, periodUs)
+	return shWrap(script, inner)
+}
+
+// readOnlyRootWrap strips write permission from /workspace before exec'ing
+// inner. This uses chmod rather than a bind-remount so it doesn't need
+// CAP_SYS_ADMIN the way remounting would; like cgroupWrap's quota, it's
+// best-effort (a script running as root inside the container could chmod
+// its way back to writable), not an airtight guarantee.
+func readOnlyRootWrap(inner []string) []string {
+	script := `chmod -R a-w /workspace 2>/dev/null || true
+exec "$@"
+`
+	return shWrap(script, inner)
+}
+
+// proxySidecarPort is the port the network-policy proxy sidecar listens on.
+const proxySidecarPort = 8888
+
+// applyNetworkPolicy binds container to a per-call tinyproxy sidecar and
+// points HTTP_PROXY/HTTPS_PROXY at it, instead of granting the sandboxed
+// container CAP_NET_ADMIN to set up its own network namespace or iptables
+// rules. See networkProxyService for what this does and doesn't block.
+func applyNetworkPolicy(container *Container, policy *SafetyPolicy) *Container {
+	var proxy *Service
+	switch policy.NetworkMode {
+	case "none", "offline":
+		proxy = networkProxyService(nil)
+	case "allowlist":
+		proxy = networkProxyService(policy.AllowedHosts)
+	default:
+		return container
+	}
+
+	proxyURL := fmt.Sprintf("http://safety-proxy:%d", proxySidecarPort)
+	return container.
+		WithServiceBinding("safety-proxy", proxy).
+		WithEnvVariable("HTTP_PROXY", proxyURL).
+		WithEnvVariable("HTTPS_PROXY", proxyURL).
+		WithEnvVariable("NO_PROXY", "")
+}
+
+// networkProxyService returns a tinyproxy sidecar configured to forward only
+// to allowedHosts (or, when nil, nothing at all beyond what tinyproxy itself
+// needs). This bounds network access by controlling a proxy the sandboxed
+// container doesn't have credentials to reconfigure, rather than by
+// manipulating the sandboxed container's own network namespace or firewall
+// rules, which would require running it with elevated capabilities. The
+// tradeoff: it only constrains HTTP(S) clients that honor the HTTP_PROXY/
+// HTTPS_PROXY env vars set by applyNetworkPolicy, not raw sockets or tools
+// that ignore them.
+func networkProxyService(allowedHosts []string) *Service {
+	return dag.Container().
+		From("debian:bookworm-slim").
+		WithExec([]string{"sh", "-c", "apt-get update -qq && apt-get install -y -qq tinyproxy >/dev/null"}).
+		WithNewFile("/etc/tinyproxy/filter", ContainerWithNewFileOpts{Contents: tinyproxyFilter(allowedHosts)}).
+		WithNewFile("/etc/tinyproxy/tinyproxy.conf", ContainerWithNewFileOpts{Contents: tinyproxyConf()}).
+		WithExposedPort(proxySidecarPort).
+		WithExec([]string{"tinyproxy", "-d"}).
+		AsService()
+}
+
+// tinyproxyFilter renders the one-host-per-line file tinyproxy's Filter
+// directive reads. An empty/nil allowedHosts renders an empty filter, which
+// combined with FilterDefaultDeny in tinyproxyConf blocks every host.
+func tinyproxyFilter(allowedHosts []string) string {
+	var filter strings.Builder
+	for _, host := range allowedHosts {
+		fmt.Fprintf(&filter, "%s\n", host)
+	}
+	return filter.String()
+}
+
+// tinyproxyConf renders tinyproxy.conf with FilterDefaultDeny set, so a
+// host reaches the sandboxed container only if it's listed in the filter
+// file tinyproxyFilter renders.
+func tinyproxyConf() string {
+	return fmt.Sprintf(`Port %d
+Listen 0.0.0.0
+Timeout 600
+FilterDefaultDeny Yes
+FilterExtended Yes
+Filter /etc/tinyproxy/filter
+`, proxySidecarPort)
+}
+
+// applySecrets mounts each secret as an environment variable via
+// WithSecretVariable so values never appear as plaintext in the Dagger
+// graph, unlike the plain env []string parameters the module used to
+// accept.
+func applySecrets(container *Container, secrets map[string]*Secret) *Container {
+	for name, secret := range secrets {
+		container = container.WithSecretVariable(name, secret)
+	}
+	return container
+}
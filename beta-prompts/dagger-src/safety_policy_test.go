@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUlimitWrap(t *testing.T) {
+	got := ulimitWrap(512, []string{"python", "script.py"})
+	if got[0] != "sh" || got[1] != "-c" {
+		t.Fatalf("ulimitWrap(...)[:2] = %v, want [sh -c ...]", got[:2])
+	}
+	if !strings.Contains(got[2], "ulimit -v 524288") {
+		t.Errorf("ulimitWrap script does not cap virtual memory in KB (512MB = 524288KB):\n%s", got[2])
+	}
+	if len(got) != 6 {
+		t.Fatalf("ulimitWrap(...) = %v, want inner command appended after the script", got)
+	}
+	if got[3] != "sh" || got[4] != "python" || got[5] != "script.py" {
+		t.Errorf("ulimitWrap(...) inner args = %v, want [sh python script.py]", got[3:])
+	}
+}
+
+func TestCgroupWrap(t *testing.T) {
+	got := cgroupWrap(50, []string{"pytest"})
+	if got[0] != "sh" || got[1] != "-c" {
+		t.Fatalf("cgroupWrap(...)[:2] = %v, want [sh -c ...]", got[:2])
+	}
+	if !strings.Contains(got[2], "50000 100000") {
+		t.Errorf("cgroupWrap script does not write the expected cpu.max quota/period:\n%s", got[2])
+	}
+	if !strings.Contains(got[2], "|| true") {
+		t.Errorf("cgroupWrap script is not best-effort (missing `|| true` fallback):\n%s", got[2])
+	}
+	if got[3] != "sh" || got[4] != "pytest" {
+		t.Errorf("cgroupWrap(...) inner args = %v, want [sh pytest]", got[3:])
+	}
+}
+
+func TestReadOnlyRootWrap(t *testing.T) {
+	got := readOnlyRootWrap([]string{"python", "script.py"})
+	if got[0] != "sh" || got[1] != "-c" {
+		t.Fatalf("readOnlyRootWrap(...)[:2] = %v, want [sh -c ...]", got[:2])
+	}
+	if !strings.Contains(got[2], "chmod -R a-w /workspace") {
+		t.Errorf("readOnlyRootWrap script does not strip write permission from /workspace:\n%s", got[2])
+	}
+	if got[3] != "sh" || got[4] != "python" || got[5] != "script.py" {
+		t.Errorf("readOnlyRootWrap(...) inner args = %v, want [sh python script.py]", got[3:])
+	}
+}
+
+// TestTinyproxyFilterOnlyListsAllowedHosts guards the DNS/allowlist-bypass
+// class of bug: the filter file is the only thing standing between
+// "allowlist" mode and every host being reachable, so it must contain
+// exactly the hosts passed in and nothing else.
+func TestTinyproxyFilterOnlyListsAllowedHosts(t *testing.T) {
+	filter := tinyproxyFilter([]string{"pypi.org", "files.pythonhosted.org"})
+	lines := strings.Split(strings.TrimRight(filter, "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "pypi.org" || lines[1] != "files.pythonhosted.org" {
+		t.Errorf("tinyproxyFilter(...) = %q, want exactly the two allowed hosts, one per line", filter)
+	}
+}
+
+func TestTinyproxyFilterEmptyForNoAllowedHosts(t *testing.T) {
+	if got := tinyproxyFilter(nil); got != "" {
+		t.Errorf("tinyproxyFilter(nil) = %q, want empty string so FilterDefaultDeny blocks everything", got)
+	}
+}
+
+// TestTinyproxyConfDefaultDenies guards against the filter being advisory
+// rather than enforced: without FilterDefaultDeny, an empty/short filter
+// list would pass every request through rather than blocking unlisted
+// hosts.
+func TestTinyproxyConfDefaultDenies(t *testing.T) {
+	conf := tinyproxyConf()
+	if !strings.Contains(conf, "FilterDefaultDeny Yes") {
+		t.Errorf("tinyproxyConf() does not set FilterDefaultDeny Yes:\n%s", conf)
+	}
+	if !strings.Contains(conf, "Filter /etc/tinyproxy/filter") {
+		t.Errorf("tinyproxyConf() does not point Filter at the rendered filter file:\n%s", conf)
+	}
+}
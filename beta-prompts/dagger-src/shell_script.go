@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// scriptShell identifies which script dialect ShellScript compiles commands
+// into.
+type scriptShell string
+
+const (
+	shellPOSIX      scriptShell = "sh"
+	shellPowerShell scriptShell = "pwsh"
+)
+
+// powerShellImage is a base image with pwsh preinstalled. The default
+// m.Container base (python:3.11-slim) has no PowerShell, so pwsh scripts
+// run in this image instead.
+const powerShellImage = "mcr.microsoft.com/powershell:lts-debian-12"
+
+// ShellScript compiles commands into a single script and executes it as one
+// step, instead of callers hand-concatenating commands into one `sh -c`
+// string the way ShellCommand requires. Each command is echoed before it
+// runs (`+ cmd`) and wrapped in a numbered step boundary so a failure can be
+// attributed to the specific line that caused it.
+func (m *BetaPromptsSafety) ShellScript(
+	ctx context.Context,
+	// Source directory
+	source *Directory,
+	// Commands to run in order
+	commands []string,
+	// +optional
+	// Shell dialect: "sh" (default) or "pwsh"
+	shell string,
+	// +optional
+	// Environment variables
+	env []string,
+	// +optional
+	// Secret environment variables, mounted via WithSecretVariable instead of plaintext
+	secrets map[string]*Secret,
+	// +optional
+	// Network, resource, and filesystem restrictions for the sandbox
+	policy *SafetyPolicy,
+) (*RunResult, error) {
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("ShellScript: commands must not be empty")
+	}
+
+	dialect := scriptShell(shell)
+	if dialect == "" {
+		dialect = shellPOSIX
+	}
+
+	script, scriptPath, execArgs, err := compileScript(dialect, commands)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := policyContext(ctx, policy)
+	defer cancel()
+
+	var container *Container
+	if dialect == shellPowerShell {
+		container = dag.Container().
+			From(powerShellImage).
+			WithWorkdir("/workspace").
+			WithDirectory("/workspace", source)
+	} else {
+		container = m.Container(ctx, "linux/amd64").
+			WithDirectory("/workspace", source)
+	}
+	for _, e := range env {
+		container = container.WithEnvVariable(e, e)
+	}
+	container = applySecrets(container, secrets)
+
+	container = container.
+		WithNewFile(scriptPath, ContainerWithNewFileOpts{Contents: script})
+	container = execWithPolicy(container, execArgs, policy, ContainerWithExecOpts{Expect: ReturnTypeAny})
+
+	return captureRunResult(ctx, container)
+}
+
+// compileScript assembles commands into a single script for dialect,
+// returning its contents, the path it should be written to, and the exec
+// args that run it.
+func compileScript(dialect scriptShell, commands []string) (script, path string, execArgs []string, err error) {
+	switch dialect {
+	case shellPOSIX:
+		var b strings.Builder
+		b.WriteString("set -e\n")
+		b.WriteString("set -o pipefail\n")
+		for i, cmd := range commands {
+			fmt.Fprintf(&b, "echo '--- step %d ---'\n", i+1)
+			fmt.Fprintf(&b, "echo '+ %s'\n", escapeSingleQuotesPOSIX(cmd))
+			b.WriteString(cmd)
+			b.WriteString("\n")
+		}
+		return b.String(), "/workspace/.dagger-script.sh", []string{"sh", "/workspace/.dagger-script.sh"}, nil
+
+	case shellPowerShell:
+		var b strings.Builder
+		b.WriteString("$ErrorActionPreference = 'Stop'\n")
+		for i, cmd := range commands {
+			fmt.Fprintf(&b, "Write-Host '--- step %d ---'\n", i+1)
+			fmt.Fprintf(&b, "Write-Host '+ %s'\n", escapeSingleQuotesPowerShell(cmd))
+			b.WriteString(cmd)
+			b.WriteString("\n")
+		}
+		return b.String(), "/workspace/.dagger-script.ps1", []string{"pwsh", "-File", "/workspace/.dagger-script.ps1"}, nil
+
+	default:
+		return "", "", nil, fmt.Errorf("unsupported shell dialect %q", dialect)
+	}
+}
+
+// escapeSingleQuotesPOSIX escapes a string for embedding inside a POSIX
+// sh single-quoted string: close the quote, emit a backslash-escaped
+// literal quote, then reopen the quote (a'b becomes a three-part
+// concatenation sh rejoins into a single word).
+func escapeSingleQuotesPOSIX(s string) string {
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
+
+// escapeSingleQuotesPowerShell escapes a string for embedding inside a
+// PowerShell single-quoted string, where a literal quote is written by
+// doubling it.
+func escapeSingleQuotesPowerShell(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
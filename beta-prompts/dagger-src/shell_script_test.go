@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEscapeSingleQuotesPOSIX guards against reusing PowerShell's
+// quote-doubling for POSIX sh: doubling the quote instead of using the
+// close/escape/reopen idiom silently drops it, turning a'b into ab once sh
+// evaluates the doubled quote as an empty string instead of a literal one.
+func TestEscapeSingleQuotesPOSIX(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"no quotes", "echo hi", "echo hi"},
+		{"single quote", "a'b", `a'\''b`},
+		{"leading quote", "'a", `'\''a`},
+		{"trailing quote", "a'", `a'\''`},
+		{"multiple quotes", "it's a 'test'", `it'\''s a '\''test'\''`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := escapeSingleQuotesPOSIX(tc.input)
+			if got != tc.want {
+				t.Errorf("escapeSingleQuotesPOSIX(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEscapeSingleQuotesPowerShell(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"no quotes", "Write-Host hi", "Write-Host hi"},
+		{"single quote", "a'b", "a''b"},
+		{"multiple quotes", "it's a 'test'", "it''s a ''test''"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := escapeSingleQuotesPowerShell(tc.input)
+			if got != tc.want {
+				t.Errorf("escapeSingleQuotesPowerShell(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompileScriptPOSIX(t *testing.T) {
+	script, path, execArgs, err := compileScript(shellPOSIX, []string{"echo 'hi'"})
+	if err != nil {
+		t.Fatalf("compileScript: %v", err)
+	}
+	if path != "/workspace/.dagger-script.sh" {
+		t.Errorf("path = %q, want /workspace/.dagger-script.sh", path)
+	}
+	wantArgs := []string{"sh", "/workspace/.dagger-script.sh"}
+	if len(execArgs) != len(wantArgs) || execArgs[0] != wantArgs[0] || execArgs[1] != wantArgs[1] {
+		t.Errorf("execArgs = %v, want %v", execArgs, wantArgs)
+	}
+	if !strings.Contains(script, `'\''`) {
+		t.Errorf("compileScript(sh) did not escape the embedded quote with the '\\'' idiom:\n%s", script)
+	}
+	if !strings.HasPrefix(script, "set -e\n") {
+		t.Errorf("compileScript(sh) script does not start with `set -e`:\n%s", script)
+	}
+}
+
+func TestCompileScriptPowerShell(t *testing.T) {
+	script, path, execArgs, err := compileScript(shellPowerShell, []string{"Write-Host 'hi'"})
+	if err != nil {
+		t.Fatalf("compileScript: %v", err)
+	}
+	if path != "/workspace/.dagger-script.ps1" {
+		t.Errorf("path = %q, want /workspace/.dagger-script.ps1", path)
+	}
+	wantArgs := []string{"pwsh", "-File", "/workspace/.dagger-script.ps1"}
+	if len(execArgs) != len(wantArgs) {
+		t.Fatalf("execArgs = %v, want %v", execArgs, wantArgs)
+	}
+	for i := range wantArgs {
+		if execArgs[i] != wantArgs[i] {
+			t.Errorf("execArgs[%d] = %q, want %q", i, execArgs[i], wantArgs[i])
+		}
+	}
+	if !strings.Contains(script, "''") {
+		t.Errorf("compileScript(pwsh) did not escape the embedded quote by doubling:\n%s", script)
+	}
+}
+
+func TestCompileScriptUnsupportedDialect(t *testing.T) {
+	if _, _, _, err := compileScript(scriptShell("fish"), []string{"echo hi"}); err == nil {
+		t.Error("compileScript with an unsupported dialect: want error, got nil")
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Finding is a single issue surfaced by one of the static-analysis tools in
+// the ValidateScript pipeline.
+type Finding struct {
+	Tool     string `json:"tool"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport is the merged result of every tool in the
+// ValidateScript pipeline.
+type ValidationReport struct {
+	Findings []Finding `json:"findings"`
+}
+
+// severityRank orders severities from least to most serious so callers can
+// threshold on them; unrecognized severities rank below everything.
+var severityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// venvSitePackages is where pip-audit looks for the project's own
+// dependencies inside the venv Venv builds, so findings reflect what the
+// script actually imports rather than the base image's packages.
+const venvSitePackages = venvPath + "/lib/python3.11/site-packages"
+
+// ValidateScript runs script through a pipeline of static-analysis tools
+// (bandit, ruff, pip-audit, and semgrep) and returns the merged findings
+// as a ValidationReport JSON document; a tool that fails to run is reported
+// as a critical "tool-error" finding rather than skipped. Syntax is checked
+// first via py_compile/ast.parse so a script that doesn't even parse fails
+// fast with a single finding instead of noisy tool errors. The project's
+// own venv is built first so pip-audit audits the script's actual
+// third-party dependencies instead of just the analysis tools' own.
+func (m *BetaPromptsSafety) ValidateScript(
+	ctx context.Context,
+	// Source directory
+	source *Directory,
+	// Script to validate
+	script string,
+) (string, error) {
+	_, report, err := m.runValidationPipeline(ctx, source, script)
+	if err != nil {
+		return "", err
+	}
+	return marshalValidationReport(report)
+}
+
+// runValidationPipeline builds the project venv, installs the analysis
+// tools, and runs the bandit/ruff/pip-audit/semgrep pipeline against
+// script, returning the syntax-check RunResult alongside the merged
+// ValidationReport. ValidateScript and ValidateScriptJSON both call this
+// instead of each building their own container, so the two can't drift the
+// way ValidateScriptJSON once did, re-running only the syntax gate while
+// ValidateScript grew the rest of the pipeline around it.
+func (m *BetaPromptsSafety) runValidationPipeline(
+	ctx context.Context,
+	source *Directory,
+	script string,
+) (*RunResult, *ValidationReport, error) {
+	venv, err := m.Venv(ctx, source, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Analysis tools are installed into the system interpreter, not the
+	// project venv, so they stay independent of what pip-audit reports on.
+	container := WithVenv(m.Container(ctx, "linux/amd64"), venv).
+		WithDirectory("/workspace", source).
+		WithExec([]string{"/usr/local/bin/pip3", "install", "--quiet", "bandit", "ruff", "pip-audit", "semgrep"})
+
+	syntaxCmd := fmt.Sprintf(`python -m py_compile %s && python -c "import ast; ast.parse(open('%s').read())"`, script, script)
+	syntaxResult, err := captureRunResult(ctx, container.
+		WithExec([]string{"sh", "-c", syntaxCmd}, ContainerWithExecOpts{Expect: ReturnTypeAny}))
+	if err != nil {
+		return nil, nil, err
+	}
+	if syntaxResult.ExitCode != 0 {
+		return syntaxResult, &ValidationReport{
+			Findings: []Finding{{
+				Tool:     "py_compile",
+				Severity: "critical",
+				Rule:     "syntax-error",
+				File:     script,
+				Message:  syntaxResult.Stderr,
+			}},
+		}, nil
+	}
+
+	var findings []Finding
+	findings = append(findings, runBandit(ctx, container, script)...)
+	findings = append(findings, runRuff(ctx, container, script)...)
+	findings = append(findings, runPipAudit(ctx, container)...)
+	findings = append(findings, runSemgrep(ctx, container, script)...)
+
+	return syntaxResult, &ValidationReport{Findings: findings}, nil
+}
+
+// toolErrorFinding reports that tool couldn't be run to completion — its
+// exec failed, or it didn't produce the JSON the parser expects — as a
+// critical Finding instead of silently returning no findings. Without this,
+// a broken or missing tool looks identical to a clean scan: ValidateScript
+// would report zero findings either way, and ValidateScriptStrict would
+// pass a script that was never actually scanned.
+func toolErrorFinding(tool string, detail string) Finding {
+	return Finding{
+		Tool:     tool,
+		Severity: "critical",
+		Rule:     "tool-error",
+		Message:  fmt.Sprintf("%s did not complete successfully: %s", tool, detail),
+	}
+}
+
+// ValidateScriptStrict behaves like ValidateScript but returns a non-nil
+// error when any finding meets or exceeds minSeverity (one of "low",
+// "medium", "high", "critical"), so CI can fail the build without
+// regexing over free-form text.
+func (m *BetaPromptsSafety) ValidateScriptStrict(
+	ctx context.Context,
+	// Source directory
+	source *Directory,
+	// Script to validate
+	script string,
+	// Minimum severity that fails the call: low, medium, high, or critical
+	minSeverity string,
+) (string, error) {
+	report, err := m.ValidateScript(ctx, source, script)
+	if err != nil {
+		return report, err
+	}
+
+	threshold, ok := severityRank[minSeverity]
+	if !ok {
+		return report, fmt.Errorf("unknown severity threshold %q", minSeverity)
+	}
+
+	var parsed ValidationReport
+	if err := json.Unmarshal([]byte(report), &parsed); err != nil {
+		return report, fmt.Errorf("parsing validation report: %w", err)
+	}
+
+	if f := firstFindingAtOrAbove(parsed.Findings, threshold); f != nil {
+		return report, fmt.Errorf("validation failed: %s finding %q (%s) meets threshold %q", f.Severity, f.Rule, f.Tool, minSeverity)
+	}
+
+	return report, nil
+}
+
+// firstFindingAtOrAbove returns a pointer to the first finding in findings
+// whose severity rank is at least threshold, or nil if none qualify.
+// Unrecognized severities rank below everything, so they never trigger it.
+func firstFindingAtOrAbove(findings []Finding, threshold int) *Finding {
+	for i := range findings {
+		if severityRank[findings[i].Severity] >= threshold {
+			return &findings[i]
+		}
+	}
+	return nil
+}
+
+func marshalValidationReport(report *ValidationReport) (string, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("marshaling validation report: %w", err)
+	}
+	return string(data), nil
+}
+
+// runBandit runs `bandit -f json` over script and parses its findings.
+// Bandit exits non-zero when it has findings, so the container is run with
+// ReturnTypeAny and the JSON is parsed regardless of exit code. If bandit
+// couldn't be run or didn't produce parseable JSON, that's reported as a
+// critical finding rather than silently contributing nothing.
+func runBandit(ctx context.Context, container *Container, script string) []Finding {
+	out, err := container.
+		WithExec([]string{"bandit", "-f", "json", script}, ContainerWithExecOpts{Expect: ReturnTypeAny}).
+		Stdout(ctx)
+	if err != nil {
+		return []Finding{toolErrorFinding("bandit", err.Error())}
+	}
+
+	var parsed struct {
+		Results []struct {
+			Filename      string `json:"filename"`
+			IssueSeverity string `json:"issue_severity"`
+			TestID        string `json:"test_id"`
+			LineNumber    int    `json:"line_number"`
+			IssueText     string `json:"issue_text"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return []Finding{toolErrorFinding("bandit", fmt.Sprintf("parsing output: %v", err))}
+	}
+
+	findings := make([]Finding, 0, len(parsed.Results))
+	for _, r := range parsed.Results {
+		findings = append(findings, Finding{
+			Tool:     "bandit",
+			Severity: normalizeSeverity(r.IssueSeverity),
+			Rule:     r.TestID,
+			File:     r.Filename,
+			Line:     r.LineNumber,
+			Message:  r.IssueText,
+		})
+	}
+	return findings
+}
+
+// runRuff runs `ruff check --output-format=json` over script. Ruff doesn't
+// assign severities, so findings are reported as "low" unless the rule code
+// is in a security-relevant category ruff flags with an "S" prefix. If ruff
+// couldn't be run or didn't produce parseable JSON, that's reported as a
+// critical finding rather than silently contributing nothing.
+func runRuff(ctx context.Context, container *Container, script string) []Finding {
+	out, err := container.
+		WithExec([]string{"ruff", "check", "--output-format=json", script}, ContainerWithExecOpts{Expect: ReturnTypeAny}).
+		Stdout(ctx)
+	if err != nil {
+		return []Finding{toolErrorFinding("ruff", err.Error())}
+	}
+
+	var parsed []struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Filename string `json:"filename"`
+		Location struct {
+			Row int `json:"row"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return []Finding{toolErrorFinding("ruff", fmt.Sprintf("parsing output: %v", err))}
+	}
+
+	findings := make([]Finding, 0, len(parsed))
+	for _, r := range parsed {
+		severity := "low"
+		if len(r.Code) > 0 && r.Code[0] == 'S' {
+			severity = "medium"
+		}
+		findings = append(findings, Finding{
+			Tool:     "ruff",
+			Severity: severity,
+			Rule:     r.Code,
+			File:     r.Filename,
+			Line:     r.Location.Row,
+			Message:  r.Message,
+		})
+	}
+	return findings
+}
+
+// runPipAudit runs `pip-audit --format=json` scoped to the project venv's
+// site-packages (rather than the system interpreter's) and flags any known
+// vulnerabilities as "high". If pip-audit couldn't be run or didn't produce
+// parseable JSON, that's reported as a critical finding rather than
+// silently contributing nothing.
+func runPipAudit(ctx context.Context, container *Container) []Finding {
+	out, err := container.
+		WithExec([]string{"pip-audit", "--format=json", "--path", venvSitePackages}, ContainerWithExecOpts{Expect: ReturnTypeAny}).
+		Stdout(ctx)
+	if err != nil {
+		return []Finding{toolErrorFinding("pip-audit", err.Error())}
+	}
+
+	var parsed struct {
+		Dependencies []struct {
+			Name  string `json:"name"`
+			Vulns []struct {
+				ID          string `json:"id"`
+				Description string `json:"description"`
+			} `json:"vulns"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return []Finding{toolErrorFinding("pip-audit", fmt.Sprintf("parsing output: %v", err))}
+	}
+
+	var findings []Finding
+	for _, dep := range parsed.Dependencies {
+		for _, vuln := range dep.Vulns {
+			findings = append(findings, Finding{
+				Tool:     "pip-audit",
+				Severity: "high",
+				Rule:     vuln.ID,
+				File:     dep.Name,
+				Message:  vuln.Description,
+			})
+		}
+	}
+	return findings
+}
+
+// runSemgrep runs `semgrep --config=auto --json` over script. Unlike
+// before, a failed run or unparseable output is surfaced as a critical
+// finding rather than silently skipped, so ValidateScriptStrict can't pass a
+// script that was never actually scanned by this tool.
+func runSemgrep(ctx context.Context, container *Container, script string) []Finding {
+	out, err := container.
+		WithExec([]string{"semgrep", "--config=auto", "--json", script}, ContainerWithExecOpts{Expect: ReturnTypeAny}).
+		Stdout(ctx)
+	if err != nil {
+		return []Finding{toolErrorFinding("semgrep", err.Error())}
+	}
+
+	var parsed struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			Extra struct {
+				Severity string `json:"severity"`
+				Message  string `json:"message"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return []Finding{toolErrorFinding("semgrep", fmt.Sprintf("parsing output: %v", err))}
+	}
+
+	findings := make([]Finding, 0, len(parsed.Results))
+	for _, r := range parsed.Results {
+		findings = append(findings, Finding{
+			Tool:     "semgrep",
+			Severity: normalizeSeverity(r.Extra.Severity),
+			Rule:     r.CheckID,
+			File:     r.Path,
+			Line:     r.Start.Line,
+			Message:  r.Extra.Message,
+		})
+	}
+	return findings
+}
+
+// normalizeSeverity maps each tool's own severity vocabulary onto the
+// low/medium/high/critical scale used by severityRank.
+func normalizeSeverity(raw string) string {
+	switch raw {
+	case "LOW", "low", "INFO", "info":
+		return "low"
+	case "MEDIUM", "medium", "WARNING", "warning":
+		return "medium"
+	case "HIGH", "high", "ERROR", "error":
+		return "high"
+	case "CRITICAL", "critical":
+		return "critical"
+	default:
+		return "low"
+	}
+}
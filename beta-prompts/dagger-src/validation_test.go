@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestNormalizeSeverity(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{"LOW", "low"},
+		{"low", "low"},
+		{"INFO", "low"},
+		{"info", "low"},
+		{"MEDIUM", "medium"},
+		{"WARNING", "medium"},
+		{"HIGH", "high"},
+		{"ERROR", "high"},
+		{"CRITICAL", "critical"},
+		{"critical", "critical"},
+		{"totally-unknown", "low"},
+		{"", "low"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			if got := normalizeSeverity(tc.raw); got != tc.want {
+				t.Errorf("normalizeSeverity(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFirstFindingAtOrAbove(t *testing.T) {
+	findings := []Finding{
+		{Tool: "ruff", Severity: "low", Rule: "E501"},
+		{Tool: "bandit", Severity: "high", Rule: "B602"},
+		{Tool: "pip-audit", Severity: "critical", Rule: "CVE-1234"},
+	}
+
+	cases := []struct {
+		name      string
+		threshold int
+		wantRule  string
+		wantNil   bool
+	}{
+		{"threshold above everything", severityRank["critical"] + 1, "", true},
+		{"threshold matches only critical", severityRank["critical"], "CVE-1234", false},
+		{"threshold matches high and above", severityRank["high"], "B602", false},
+		{"threshold matches everything", severityRank["low"], "E501", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := firstFindingAtOrAbove(findings, tc.threshold)
+			if tc.wantNil {
+				if got != nil {
+					t.Errorf("firstFindingAtOrAbove() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("firstFindingAtOrAbove() = nil, want finding with rule %q", tc.wantRule)
+			}
+			if got.Rule != tc.wantRule {
+				t.Errorf("firstFindingAtOrAbove() rule = %q, want %q", got.Rule, tc.wantRule)
+			}
+		})
+	}
+}
+
+func TestFirstFindingAtOrAboveNoFindings(t *testing.T) {
+	if got := firstFindingAtOrAbove(nil, severityRank["low"]); got != nil {
+		t.Errorf("firstFindingAtOrAbove(nil, ...) = %+v, want nil", got)
+	}
+}
+
+func TestFirstFindingAtOrAboveUnrecognizedSeverityNeverMatches(t *testing.T) {
+	findings := []Finding{{Tool: "mystery", Severity: "weird", Rule: "X"}}
+	if got := firstFindingAtOrAbove(findings, severityRank["low"]); got != nil {
+		t.Errorf("firstFindingAtOrAbove() = %+v, want nil (unrecognized severities rank below everything)", got)
+	}
+}
+
+func TestToolErrorFinding(t *testing.T) {
+	f := toolErrorFinding("bandit", "exit status 127")
+	if f.Tool != "bandit" {
+		t.Errorf("Tool = %q, want %q", f.Tool, "bandit")
+	}
+	if f.Severity != "critical" {
+		t.Errorf("Severity = %q, want %q", f.Severity, "critical")
+	}
+	if f.Rule != "tool-error" {
+		t.Errorf("Rule = %q, want %q", f.Rule, "tool-error")
+	}
+}
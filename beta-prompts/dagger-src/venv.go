@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const venvPath = "/opt/venv"
+
+// lockFiles are, in priority order, the files whose contents determine the
+// pip wheel cache key for a given source tree.
+var lockFiles = []string{"poetry.lock", "requirements.txt", "pyproject.toml"}
+
+// Venv builds a virtualenv at /opt/venv for source and returns it as an
+// exportable Directory so callers can reuse it across invocations instead of
+// re-installing dependencies on every PythonScript/RunTests call. extras are
+// passed through to `pip install` as `.[extra1,extra2]`.
+func (m *BetaPromptsSafety) Venv(
+	ctx context.Context,
+	// Source directory. Its pyproject.toml, if present, is installed in
+	// editable mode; a source tree with no pyproject.toml (a standalone
+	// script) gets an empty venv instead of a failed call.
+	source *Directory,
+	// +optional
+	// Extra dependency groups to install, e.g. ["test", "dev"]
+	extras []string,
+) (*Directory, error) {
+	return m.venvOn(ctx, m.Container(ctx, "linux/amd64"), source, extras, "")
+}
+
+// venvOn builds a virtualenv at /opt/venv on top of an already-prepared base
+// container, instead of always building its own from m.Container, so
+// callers like runTestsOn can reuse a per-interpreter-version base and still
+// get venv/pip-cache reuse. cacheKeySuffix is mixed into the pip wheel cache
+// key so bases that aren't binary-compatible (different Python versions)
+// don't share a cache volume.
+func (m *BetaPromptsSafety) venvOn(
+	ctx context.Context,
+	container *Container,
+	source *Directory,
+	extras []string,
+	cacheKeySuffix string,
+) (*Directory, error) {
+	cacheKey, err := dependencyCacheKey(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKeySuffix != "" {
+		cacheKey = cacheKeySuffix + "-" + cacheKey
+	}
+
+	target := "."
+	if len(extras) > 0 {
+		target = ".[" + joinComma(extras) + "]"
+	}
+
+	// Guarded the same way baseline installed project deps: a standalone
+	// script with no pyproject.toml has nothing for `pip install -e` to
+	// install, and running it unconditionally turns every such script into
+	// a hard failure instead of just getting an empty venv.
+	installCmd := fmt.Sprintf("if [ -f pyproject.toml ]; then %s/bin/pip install -e %q; fi", venvPath, target)
+
+	container = container.
+		WithDirectory("/workspace", source).
+		WithExec([]string{"python", "-m", "venv", venvPath}).
+		WithMountedCache("/root/.cache/pip", dag.CacheVolume("pip-wheels-"+cacheKey)).
+		WithExec([]string{venvPath + "/bin/pip", "install", "--upgrade", "pip"}).
+		WithExec([]string{"sh", "-c", installCmd})
+
+	return container.Directory(venvPath), nil
+}
+
+// WithVenv mounts a previously built venv into container and prepends its
+// bin/ directory to PATH so `python`/`pip` resolve inside it without an
+// activate step.
+func WithVenv(container *Container, venv *Directory) *Container {
+	return container.
+		WithDirectory(venvPath, venv).
+		WithEnvVariable("PATH", venvPath+"/bin:$PATH", ContainerWithEnvVariableOpts{Expand: true}).
+		WithEnvVariable("VIRTUAL_ENV", venvPath)
+}
+
+// dependencyCacheKey hashes the first lock file found in source (in
+// lockFiles priority order) so the pip wheel cache is invalidated only when
+// dependencies actually change.
+func dependencyCacheKey(ctx context.Context, source *Directory) (string, error) {
+	for _, name := range lockFiles {
+		contents, err := source.File(name).Contents(ctx)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(sum[:])[:16], nil
+	}
+	return "no-lockfile", nil
+}
+
+func joinComma(items []string) string {
+	out := ""
+	for i, item := range items {
+		if i > 0 {
+			out += ","
+		}
+		out += item
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+// TestJoinComma covers joinComma, the one pure helper in venv.go; the rest
+// of the file builds on *Directory/*Container and needs a live Dagger
+// engine to exercise, same as the rest of the module.
+func TestJoinComma(t *testing.T) {
+	cases := []struct {
+		name  string
+		items []string
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"single", []string{"test"}, "test"},
+		{"multiple", []string{"test", "dev"}, "test,dev"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := joinComma(tc.items); got != tc.want {
+				t.Errorf("joinComma(%v) = %q, want %q", tc.items, got, tc.want)
+			}
+		})
+	}
+}